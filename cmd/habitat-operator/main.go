@@ -0,0 +1,185 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+	"github.com/kinvolk/habitat-operator/pkg/habitat/controller"
+)
+
+const (
+	defaultLeaseNamespace = "default"
+	defaultLeaseName      = "habitat-operator-leader"
+)
+
+var (
+	kubeconfig     = flag.String("kubeconfig", "", "Path to a kubeconfig file. Only required when running outside of a cluster.")
+	leaderElect    = flag.Bool("leader-elect", false, "Enable leader election, so that only one replica of the operator actively reconciles resources at a time.")
+	leaseNamespace = flag.String("leader-elect-lease-namespace", defaultLeaseNamespace, "Namespace in which the leader election lock is created. Only used when --leader-elect is set.")
+	leaseName      = flag.String("leader-elect-lease-name", defaultLeaseName, "Name of the leader election lock. Only used when --leader-elect is set.")
+	leaseDuration  = flag.Duration("leader-elect-lease-duration", 15*time.Second, "Duration that non-leader candidates wait before force-acquiring leadership.")
+	renewDeadline  = flag.Duration("leader-elect-renew-deadline", 10*time.Second, "Duration the leader retries refreshing leadership before giving it up.")
+	retryPeriod    = flag.Duration("leader-elect-retry-period", 2*time.Second, "Duration clients wait between actions during leader election.")
+
+	namespace       = flag.String("namespace", "", "Namespace to watch for Habitats, in single-namespace mode. Mutually exclusive with --watch-namespaces.")
+	watchNamespaces = flag.String("watch-namespaces", "", "Comma-separated list of namespaces to watch for Habitats. Mutually exclusive with --namespace. Leave both unset to watch every namespace.")
+
+	metricsAddr = flag.String("metrics-address", ":8080", "Address to serve Prometheus metrics on, at /metrics.")
+)
+
+func main() {
+	flag.Parse()
+
+	logger := log.NewLogfmtLogger(os.Stderr)
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+
+	if err := run(logger); err != nil {
+		level.Error(logger).Log("msg", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger log.Logger) error {
+	namespaces, err := watchedNamespaces(*namespace, *watchNamespaces)
+	if err != nil {
+		return err
+	}
+
+	restConfig, err := clientConfig(*kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build client config: %s", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes clientset: %s", err)
+	}
+
+	habitatClient, scheme, err := crv1.NewClient(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Habitat REST client: %s", err)
+	}
+
+	config := controller.Config{
+		HabitatClient:       habitatClient,
+		KubernetesClientset: clientset,
+		Scheme:              scheme,
+
+		LeaderElection: *leaderElect,
+		LeaseNamespace: *leaseNamespace,
+		LeaseName:      *leaseName,
+		LeaseDuration:  *leaseDuration,
+		RenewDeadline:  *renewDeadline,
+		RetryPeriod:    *retryPeriod,
+
+		Namespaces: namespaces,
+	}
+
+	hc, err := controller.New(config, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create controller: %s", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	hc.RegisterMetrics(registry)
+	serveMetrics(*metricsAddr, registry, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		level.Info(logger).Log("msg", "received shutdown signal, stopping controller")
+		cancel()
+	}()
+
+	if err := hc.Run(ctx); err != nil && err != context.Canceled {
+		return err
+	}
+
+	return nil
+}
+
+// serveMetrics starts an HTTP server exposing reg at /metrics in the
+// background. It doesn't block startup on listener errors, since metrics
+// are diagnostic and shouldn't prevent the controller from reconciling.
+func serveMetrics(addr string, reg *prometheus.Registry, logger log.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			level.Error(logger).Log("msg", "metrics server stopped", "err", err)
+		}
+	}()
+}
+
+// clientConfig builds a rest.Config from kubeconfigPath when it is set, and
+// from the in-cluster configuration otherwise.
+func clientConfig(kubeconfigPath string) (*rest.Config, error) {
+	if kubeconfigPath != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	}
+
+	return rest.InClusterConfig()
+}
+
+// watchedNamespaces turns the --namespace/--watch-namespaces flags into the
+// list the controller should watch. An empty result means "every
+// namespace".
+func watchedNamespaces(namespace, watchNamespaces string) ([]string, error) {
+	if namespace != "" && watchNamespaces != "" {
+		return nil, fmt.Errorf("--namespace and --watch-namespaces are mutually exclusive")
+	}
+
+	if namespace != "" {
+		return []string{namespace}, nil
+	}
+
+	if watchNamespaces != "" {
+		var namespaces []string
+		for _, ns := range strings.Split(watchNamespaces, ",") {
+			ns = strings.TrimSpace(ns)
+			if ns == "" {
+				continue
+			}
+			namespaces = append(namespaces, ns)
+		}
+
+		return namespaces, nil
+	}
+
+	return nil, nil
+}
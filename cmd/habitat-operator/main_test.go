@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWatchedNamespaces(t *testing.T) {
+	tests := []struct {
+		name            string
+		namespace       string
+		watchNamespaces string
+		want            []string
+		wantErr         bool
+	}{
+		{"both unset watches everything", "", "", nil, false},
+		{"single namespace", "foo", "", []string{"foo"}, false},
+		{"comma-separated list", "", "foo,bar", []string{"foo", "bar"}, false},
+		{"list trims whitespace and drops empties", "", "foo, ,bar,", []string{"foo", "bar"}, false},
+		{"mutually exclusive flags", "foo", "bar", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := watchedNamespaces(tt.namespace, tt.watchNamespaces)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("watchedNamespaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("watchedNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
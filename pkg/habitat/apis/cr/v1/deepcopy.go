@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+// The methods below are hand-written instead of running deepcopy-gen, since
+// this package has no generate directives wired up yet; they're written to
+// match what deepcopy-gen would produce.
+
+// DeepCopyInto copies all of in into out.
+func (in *HabitatSpec) DeepCopyInto(out *HabitatSpec) {
+	*out = *in
+	if in.Service.Bind != nil {
+		out.Service.Bind = make([]Bind, len(in.Service.Bind))
+		copy(out.Service.Bind, in.Service.Bind)
+	}
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *HabitatSpec) DeepCopy() *HabitatSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HabitatSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all of in into out.
+func (in *HabitatStatus) DeepCopyInto(out *HabitatStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]HabitatCondition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].LastTransitionTime.DeepCopyInto(&out.Conditions[i].LastTransitionTime)
+			out.Conditions[i].Type = in.Conditions[i].Type
+			out.Conditions[i].Status = in.Conditions[i].Status
+			out.Conditions[i].Reason = in.Conditions[i].Reason
+			out.Conditions[i].Message = in.Conditions[i].Message
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *HabitatStatus) DeepCopy() *HabitatStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HabitatStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all of in into out.
+func (in *Habitat) DeepCopyInto(out *Habitat) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *Habitat) DeepCopy() *Habitat {
+	if in == nil {
+		return nil
+	}
+	out := new(Habitat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *Habitat) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies all of in into out.
+func (in *HabitatList) DeepCopyInto(out *HabitatList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]Habitat, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *HabitatList) DeepCopy() *HabitatList {
+	if in == nil {
+		return nil
+	}
+	out := new(HabitatList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *HabitatList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies all of in into out.
+func (in *ServiceGroup) DeepCopyInto(out *ServiceGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *ServiceGroup) DeepCopy() *ServiceGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *ServiceGroup) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies all of in into out.
+func (in *ServiceGroupList) DeepCopyInto(out *ServiceGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ServiceGroup, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of in.
+func (in *ServiceGroupList) DeepCopy() *ServiceGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *ServiceGroupList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// HabitatResourcePlural is the plural used in the Habitat CRD's name.
+	HabitatResourcePlural = "habitats"
+	// ServiceGroupResourcePlural is the plural used in the ServiceGroup
+	// CRD's name.
+	ServiceGroupResourcePlural = "servicegroups"
+
+	// HabitatNameLabel is the Pod label, holding the name of the owning
+	// Habitat, that the e2e framework selects Pods by. The controller does
+	// not set it: the Pods it creates are labelled "service-group" instead.
+	HabitatNameLabel = "habitat-name"
+)
+
+// Topology describes how the Pods in a Service bootstrap their Habitat
+// supervisor ring.
+type Topology string
+
+const (
+	// TopologyStandalone runs every Pod as an independent Supervisor, with
+	// no peers.
+	TopologyStandalone Topology = "standalone"
+	// TopologyLeader forms a ring via a StatefulSet and headless Service,
+	// electing one Supervisor as the leader.
+	TopologyLeader Topology = "leader"
+	// TopologyInitialPeer forms a ring the same way as TopologyLeader, but
+	// seeds it as the first peer other Habitats join as followers.
+	TopologyInitialPeer Topology = "initial_peer"
+)
+
+// Bind describes a service binding between two Services, passed to the
+// Supervisor as a --bind flag.
+type Bind struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+	Group   string `json:"group"`
+}
+
+// Service configures the Habitat Supervisor running in each Pod.
+type Service struct {
+	Group            string   `json:"group,omitempty"`
+	Topology         Topology `json:"topology,omitempty"`
+	ConfigSecretName string   `json:"configSecretName,omitempty"`
+	Bind             []Bind   `json:"bind,omitempty"`
+}
+
+// HabitatComponent configures the Habitat package the Supervisor runs.
+type HabitatComponent struct {
+	Group string `json:"group,omitempty"`
+}
+
+// HabitatSpec is the spec shared by Habitat and ServiceGroup objects.
+type HabitatSpec struct {
+	Image   string           `json:"image"`
+	Count   int              `json:"count"`
+	Service Service          `json:"service,omitempty"`
+	Habitat HabitatComponent `json:"habitat,omitempty"`
+}
+
+// HabitatCondition is one observation of a Habitat/ServiceGroup's state, in
+// the same Type/Status/Reason/Message/LastTransitionTime shape as the
+// built-in Kubernetes resources use.
+type HabitatCondition struct {
+	Type               string                `json:"type"`
+	Status             apiv1.ConditionStatus `json:"status"`
+	Reason             string                `json:"reason,omitempty"`
+	Message            string                `json:"message,omitempty"`
+	LastTransitionTime metav1.Time           `json:"lastTransitionTime,omitempty"`
+}
+
+// HabitatStatus is the observed state of a Habitat/ServiceGroup, reported on
+// the /status subresource.
+type HabitatStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Replicas           int32              `json:"replicas,omitempty"`
+	ReadyReplicas      int32              `json:"readyReplicas,omitempty"`
+	LeaderIP           string             `json:"leaderIP,omitempty"`
+	Conditions         []HabitatCondition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Habitat is the original CRD kind the e2e test framework drives.
+type Habitat struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HabitatSpec   `json:"spec"`
+	Status HabitatStatus `json:"status,omitempty"`
+}
+
+// HabitatList is a list of Habitats.
+type HabitatList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Habitat `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceGroup is the CRD kind the controller reconciles.
+type ServiceGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HabitatSpec   `json:"spec"`
+	Status HabitatStatus `json:"status,omitempty"`
+}
+
+// ServiceGroupList is a list of ServiceGroups.
+type ServiceGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ServiceGroup `json:"items"`
+}
@@ -24,14 +24,18 @@ import (
 	"github.com/go-kit/kit/log/level"
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
 )
@@ -39,17 +43,66 @@ import (
 const (
 	resyncPeriod = 1 * time.Minute
 	peerFile     = "peer-watch-file"
+
+	// defaultLeaseDuration is the duration that non-leader candidates will
+	// wait to force acquire leadership.
+	defaultLeaseDuration = 15 * time.Second
+	// defaultRenewDeadline is the duration that the acting leader will retry
+	// refreshing leadership before giving it up.
+	defaultRenewDeadline = 10 * time.Second
+	// defaultRetryPeriod is the duration clients should wait between tries of
+	// actions.
+	defaultRetryPeriod = 2 * time.Second
+
+	// defaultWorkers is the number of reconcile workers started when
+	// Config.Workers is left unset.
+	defaultWorkers = 2
 )
 
 type HabitatController struct {
 	config Config
 	logger log.Logger
+
+	// queue holds the namespace/name keys of ServiceGroups that need to be
+	// reconciled. Informer handlers only ever enqueue keys; all API work
+	// happens in the workers that drain the queue.
+	queue workqueue.RateLimitingInterface
+
+	// sgIndexers is kept in sync by the ServiceGroup informers (one per
+	// watched namespace) and is used to fetch the latest known state of an
+	// object by key instead of hitting the API server on every reconcile.
+	sgIndexers []cache.Indexer
 }
 
 type Config struct {
 	HabitatClient       *rest.RESTClient
-	KubernetesClientset *kubernetes.Clientset
+	KubernetesClientset kubernetes.Interface
 	Scheme              *runtime.Scheme
+
+	// LeaderElection, when enabled, ensures that only one replica of the
+	// controller is actively reconciling resources at a time, while the
+	// others stand by ready to take over if it stops renewing its lease.
+	LeaderElection bool
+	// LeaseNamespace is the namespace the leader election lock lives in.
+	LeaseNamespace string
+	// LeaseName is the name of the lock resource used for leader election.
+	LeaseName string
+	// LeaseDuration, RenewDeadline and RetryPeriod tune how aggressively
+	// candidates compete for leadership. See client-go's leaderelection
+	// package for a detailed explanation of each value.
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	// Workers is the number of goroutines processing the reconcile
+	// workqueue concurrently. Defaults to defaultWorkers when unset.
+	Workers int
+
+	// Namespaces lists the namespaces the controller watches for
+	// ServiceGroups and Pods: one informer pair is started per entry. Leave
+	// empty (or set to a single apiv1.NamespaceAll entry) to watch every
+	// namespace.
+	Namespaces []string
 }
 
 func New(config Config, logger log.Logger) (*HabitatController, error) {
@@ -65,22 +118,72 @@ func New(config Config, logger log.Logger) (*HabitatController, error) {
 	if logger == nil {
 		return nil, errors.New("invalid controller config: no logger")
 	}
+	if config.LeaderElection {
+		if config.LeaseNamespace == "" {
+			return nil, errors.New("invalid controller config: no LeaseNamespace")
+		}
+		if config.LeaseName == "" {
+			return nil, errors.New("invalid controller config: no LeaseName")
+		}
+		if config.LeaseDuration == 0 {
+			config.LeaseDuration = defaultLeaseDuration
+		}
+		if config.RenewDeadline == 0 {
+			config.RenewDeadline = defaultRenewDeadline
+		}
+		if config.RetryPeriod == 0 {
+			config.RetryPeriod = defaultRetryPeriod
+		}
+	}
+	if config.Workers == 0 {
+		config.Workers = defaultWorkers
+	}
 
 	hc := &HabitatController{
 		config: config,
 		logger: logger,
+		queue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "habitat"),
 	}
 
 	return hc, nil
 }
 
-// Run starts a Habitat resource controller.
+// Run starts a Habitat resource controller. If leader election is enabled,
+// only the elected leader runs the reconciliation loops. Run blocks until ctx
+// is canceled; with leader election enabled it also returns as soon as this
+// replica loses leadership.
 func (hc *HabitatController) Run(ctx context.Context) error {
-	level.Info(hc.logger).Log("msg", "Watching Service Group objects")
+	if hc.config.LeaderElection {
+		return hc.runWithLeaderElection(ctx)
+	}
 
-	hc.watchCustomResources(ctx)
+	return hc.run(ctx)
+}
+
+// run starts watching Service Group and Pod objects, starts the reconcile
+// workers, and blocks until ctx is done. With leader election enabled, it is
+// only invoked by the currently elected leader.
+func (hc *HabitatController) run(ctx context.Context) error {
+	defer utilruntime.HandleCrash()
+	defer hc.queue.ShutDown()
+
+	namespaces := hc.watchedNamespaces()
+	level.Info(hc.logger).Log("msg", "Watching Service Group objects", "namespaces", fmt.Sprintf("%v", namespaces))
+
+	var hasSyncedFuncs []cache.InformerSynced
+	for _, namespace := range namespaces {
+		sgInformer := hc.watchCustomResources(ctx, namespace)
+		podInformer := hc.watchPods(ctx, namespace)
+		hasSyncedFuncs = append(hasSyncedFuncs, sgInformer.HasSynced, podInformer.HasSynced)
+	}
 
-	hc.watchPods(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), hasSyncedFuncs...) {
+		return errors.New("timed out waiting for informer caches to sync")
+	}
+
+	for i := 0; i < hc.config.Workers; i++ {
+		go wait.Until(hc.runWorker, time.Second, ctx.Done())
+	}
 
 	// This channel is closed when the context is canceled or times out.
 	<-ctx.Done()
@@ -89,14 +192,25 @@ func (hc *HabitatController) Run(ctx context.Context) error {
 	return ctx.Err()
 }
 
-func (hc *HabitatController) watchCustomResources(ctx context.Context) {
+// watchedNamespaces returns the namespaces the controller should start
+// informers in, defaulting to every namespace when Config.Namespaces is
+// unset.
+func (hc *HabitatController) watchedNamespaces() []string {
+	if len(hc.config.Namespaces) == 0 {
+		return []string{apiv1.NamespaceAll}
+	}
+
+	return hc.config.Namespaces
+}
+
+func (hc *HabitatController) watchCustomResources(ctx context.Context, namespace string) cache.Controller {
 	source := cache.NewListWatchFromClient(
 		hc.config.HabitatClient,
 		crv1.ServiceGroupResourcePlural,
-		apiv1.NamespaceAll,
+		namespace,
 		fields.Everything())
 
-	_, k8sController := cache.NewInformer(
+	indexer, k8sController := cache.NewIndexerInformer(
 		source,
 
 		// The object type.
@@ -107,39 +221,153 @@ func (hc *HabitatController) watchCustomResources(ctx context.Context) {
 		// Set to 0 to disable the resync.
 		resyncPeriod,
 
-		// Your custom resource event handlers.
+		// Your custom resource event handlers. These only enqueue the key of
+		// the changed object; the actual reconciliation happens in a worker.
 		cache.ResourceEventHandlerFuncs{
-			AddFunc:    hc.onAdd,
-			UpdateFunc: hc.onUpdate,
-			DeleteFunc: hc.onDelete,
-		})
+			AddFunc:    hc.enqueueServiceGroup,
+			UpdateFunc: func(oldObj, newObj interface{}) { hc.enqueueServiceGroup(newObj) },
+			DeleteFunc: hc.enqueueServiceGroup,
+		},
+		cache.Indexers{})
+
+	hc.sgIndexers = append(hc.sgIndexers, indexer)
 
 	// The k8sController will start processing events from the API.
 	go k8sController.Run(ctx.Done())
+
+	return k8sController
+}
+
+// enqueueServiceGroup adds the namespace/name key of obj to the workqueue.
+// obj may also be a cache.DeletedFinalStateUnknown tombstone, which
+// DeletionHandlingMetaNamespaceKeyFunc handles transparently.
+func (hc *HabitatController) enqueueServiceGroup(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		level.Error(hc.logger).Log("msg", err)
+		return
+	}
+
+	hc.queue.Add(key)
+}
+
+// runWorker repeatedly calls processNextItem until the queue tells it to
+// shut down. It is meant to be run in its own goroutine via wait.Until.
+func (hc *HabitatController) runWorker() {
+	for hc.processNextItem() {
+	}
 }
 
-func (hc *HabitatController) onAdd(obj interface{}) {
+// processNextItem pops a single key off the queue, reconciles it, and
+// reports the outcome back to the queue so it can apply rate limiting on
+// failure. It returns false when the queue is shutting down.
+func (hc *HabitatController) processNextItem() bool {
+	key, quit := hc.queue.Get()
+	if quit {
+		return false
+	}
+	defer hc.queue.Done(key)
+
+	start := time.Now()
+	err := hc.reconcile(key.(string))
+	observeReconcile(start, err)
+
+	if err == nil {
+		hc.queue.Forget(key)
+		return true
+	}
+
+	level.Error(hc.logger).Log("msg", "error reconciling ServiceGroup, retrying", "key", key, "err", err)
+	hc.queue.AddRateLimited(key)
+
+	return true
+}
+
+// getServiceGroup looks up key across every namespace's indexer. There is
+// one indexer per watched namespace, so unlike a single shared informer, the
+// key isn't guaranteed to be in the first one.
+func (hc *HabitatController) getServiceGroup(key string) (interface{}, bool, error) {
+	for _, indexer := range hc.sgIndexers {
+		obj, exists, err := indexer.GetByKey(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if exists {
+			return obj, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// reconcile brings the cluster state for the ServiceGroup identified by key
+// in line with its desired state. It is idempotent: it may be called
+// multiple times for the same key, including after a previous call partially
+// failed.
+func (hc *HabitatController) reconcile(key string) error {
+	obj, exists, err := hc.getServiceGroup(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// The finalizer has already run to completion and the object is
+		// gone; nothing left to do.
+		return nil
+	}
+
 	sg, ok := obj.(*crv1.ServiceGroup)
 	if !ok {
-		level.Error(hc.logger).Log("msg", "unknown event type")
-		return
+		return fmt.Errorf("unexpected object type in indexer for key %q", key)
 	}
 
-	level.Debug(hc.logger).Log("function", "onAdd", "msg", sg.ObjectMeta.SelfLink)
+	if sg.ObjectMeta.DeletionTimestamp != nil {
+		return hc.finalizeServiceGroup(sg)
+	}
+
+	if !hasFinalizer(sg) {
+		if err := hc.addFinalizer(sg); err != nil {
+			return err
+		}
+	}
+
+	reconcileErr := hc.reconcileServiceGroup(sg)
+	hc.updateStatus(sg, reconcileErr)
+
+	return reconcileErr
+}
+
+// reconcileServiceGroup validates sg and then reconciles the resources for
+// it. Standalone ServiceGroups keep the original Deployment + peer-file
+// ConfigMap path; every other topology forms a supervisor ring via a
+// StatefulSet and headless Service instead.
+func (hc *HabitatController) reconcileServiceGroup(sg *crv1.ServiceGroup) error {
+	level.Debug(hc.logger).Log("function", "reconcile", "msg", sg.ObjectMeta.SelfLink)
 
 	// Validate object.
 	if err := validateCustomObject(*sg); err != nil {
 		if vErr, ok := err.(validationError); ok {
 			level.Error(hc.logger).Log("type", "validation error", "msg", err, "key", vErr.Key)
-			return
+			// Validation errors will never succeed on retry, so don't
+			// return an error here: that would just spin the rate limiter.
+			return nil
 		}
 
-		level.Error(hc.logger).Log("msg", err)
-		return
+		return err
 	}
 
 	level.Debug(hc.logger).Log("msg", "validated object")
 
+	if sg.Spec.Service.Topology != crv1.TopologyStandalone {
+		return hc.reconcileRing(sg)
+	}
+
+	return hc.reconcileStandalone(sg)
+}
+
+// reconcileStandalone creates the Deployment and ConfigMap for a Standalone
+// sg if they don't already exist. It is safe to call repeatedly.
+func (hc *HabitatController) reconcileStandalone(sg *crv1.ServiceGroup) error {
 	group := "default"
 	if sg.Spec.Habitat.Group != "" {
 		group = sg.Spec.Habitat.Group
@@ -149,6 +377,8 @@ func (hc *HabitatController) onAdd(obj interface{}) {
 	// variable and afterwards pass a pointer to it.
 	count := int32(sg.Spec.Count)
 
+	namespace := sg.ObjectMeta.Namespace
+
 	// Create a deployment.
 	deployment := &appsv1beta1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -204,65 +434,109 @@ func (hc *HabitatController) onAdd(obj interface{}) {
 		},
 	}
 
-	d, err := hc.config.KubernetesClientset.AppsV1beta1Client.Deployments(apiv1.NamespaceDefault).Create(deployment)
+	d, err := hc.config.KubernetesClientset.AppsV1beta1().Deployments(namespace).Create(deployment)
 	if err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
+		if apierrors.IsAlreadyExists(err) {
+			d, err = hc.config.KubernetesClientset.AppsV1beta1().Deployments(namespace).Get(sg.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	} else {
+		level.Info(hc.logger).Log("msg", "created deployment", "name", d.GetObjectMeta().GetName())
 	}
 
-	level.Info(hc.logger).Log("msg", "created deployment", "name", d.GetObjectMeta().GetName())
-
 	// Create the ConfigMap for the peer watch file.
 	configMap := newConfigMap(sg.Name, d.UID, "")
-	_, err = hc.config.KubernetesClientset.CoreV1Client.ConfigMaps(apiv1.NamespaceDefault).Create(configMap)
+	_, err = hc.config.KubernetesClientset.CoreV1().ConfigMaps(namespace).Create(configMap)
 	if err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	} else {
+		level.Debug(hc.logger).Log("msg", "created ConfigMap with peer IP", "object", configMap.Data["peer-ip"])
 	}
 
-	level.Debug(hc.logger).Log("msg", "created ConfigMap with peer IP", "object", configMap.Data["peer-ip"])
+	return hc.reconcileLeaderIP(namespace, sg.Name, d.UID)
 }
 
-func (hc *HabitatController) onUpdate(oldObj, newObj interface{}) {
-	oldServiceGroup := oldObj.(*crv1.ServiceGroup)
-	newServiceGroup := newObj.(*crv1.ServiceGroup)
-	level.Info(hc.logger).Log("function", "onUpdate", "msg", fmt.Sprintf("oldObj: %s, newObj: %s", oldServiceGroup.ObjectMeta.SelfLink, newServiceGroup.ObjectMeta.SelfLink))
-}
+// reconcileLeaderIP makes sure the peer-file ConfigMap for the ServiceGroup
+// points at a currently running Pod. It replaces the old per-Pod-event
+// writeLeaderIP: because reconcile for a given key only ever runs in one
+// worker at a time, this removes the race between concurrent Pod add/update/
+// delete handlers that used to write the same ConfigMap.
+func (hc *HabitatController) reconcileLeaderIP(namespace, sgName string, deploymentUID types.UID) error {
+	cmName := configMapName(sgName)
 
-func (hc *HabitatController) onDelete(obj interface{}) {
-	sg, ok := obj.(*crv1.ServiceGroup)
-	if !ok {
-		level.Error(hc.logger).Log("msg", "unknown event type")
-		return
+	cm, err := hc.config.KubernetesClientset.CoreV1().ConfigMaps(namespace).Get(cmName, metav1.GetOptions{})
+	if err != nil {
+		return err
 	}
 
-	level.Debug(hc.logger).Log("function", "onDelete", "msg", sg.ObjectMeta.SelfLink)
+	currentLeaderIP := cm.Data[peerFile]
+
+	// Is the current leader still running? If so, there's nothing to do.
+	if currentLeaderIP != "" {
+		fs := fields.SelectorFromSet(fields.Set{
+			"status.podIP": currentLeaderIP,
+			"status.phase": string(apiv1.PodRunning),
+		})
 
-	deploymentsClient := hc.config.KubernetesClientset.AppsV1beta1Client.Deployments(sg.ObjectMeta.Namespace)
-	deploymentName := sg.Name
+		podList, err := hc.config.KubernetesClientset.CoreV1().Pods(namespace).List(metav1.ListOptions{FieldSelector: fs.String()})
+		if err != nil {
+			return err
+		}
 
-	// With this policy, dependent resources will be deleted, but we don't wait
-	// for that to happen.
-	deletePolicy := metav1.DeletePropagationBackground
-	deleteOptions := &metav1.DeleteOptions{
-		PropagationPolicy: &deletePolicy,
+		if len(podList.Items) > 0 {
+			return nil
+		}
 	}
 
-	err := deploymentsClient.Delete(deploymentName, deleteOptions)
+	// The current leader is gone (or there never was one): pick a new one
+	// among the running Pods for this ServiceGroup.
+	ls := labels.SelectorFromSet(labels.Set{"service-group": sgName})
+	fs := fields.SelectorFromSet(fields.Set{"status.phase": string(apiv1.PodRunning)})
+
+	podList, err := hc.config.KubernetesClientset.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: ls.String(),
+		FieldSelector: fs.String(),
+	})
 	if err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
+		return err
+	}
+
+	if len(podList.Items) == 0 {
+		// No running Pods yet; the next reconcile triggered by a Pod event
+		// will retry.
+		return nil
+	}
+
+	newLeaderIP := podList.Items[0].Status.PodIP
+	if newLeaderIP == currentLeaderIP {
+		return nil
+	}
+
+	updatedCM := newConfigMap(sgName, deploymentUID, newLeaderIP)
+	_, err = hc.config.KubernetesClientset.CoreV1().ConfigMaps(namespace).Update(updatedCM)
+	if err != nil {
+		return err
 	}
 
-	level.Info(hc.logger).Log("msg", "deleted deployment", "name", deploymentName)
+	metrics.leaderIPChanges.Inc()
+
+	level.Info(hc.logger).Log("msg", "updated leader IP", "service-group", sgName, "ip", newLeaderIP)
+
+	return nil
 }
 
-func (hc *HabitatController) watchPods(ctx context.Context) {
+func (hc *HabitatController) watchPods(ctx context.Context, namespace string) cache.Controller {
 	ls := labels.SelectorFromSet(labels.Set(map[string]string{"habitat": "true"}))
 	clw := newListWatchFromClientWithLabels(
 		hc.config.KubernetesClientset.CoreV1().RESTClient(),
 		"pods",
-		apiv1.NamespaceAll,
+		namespace,
 		ls)
 
 	_, c := cache.NewInformer(
@@ -276,11 +550,16 @@ func (hc *HabitatController) watchPods(ctx context.Context) {
 		})
 
 	go c.Run(ctx.Done())
+
+	return c
 }
 
 func (hc *HabitatController) onPodAdd(obj interface{}) {
 }
 
+// onPodUpdate no longer touches the ConfigMap directly: it only enqueues the
+// owning ServiceGroup's key, so the actual write happens serialized in a
+// reconcile worker.
 func (hc *HabitatController) onPodUpdate(oldObj, newObj interface{}) {
 	// TODO: Do not retrieve or write IP if we are deploying a standalone topology.
 	pod, ok := newObj.(*apiv1.Pod)
@@ -298,14 +577,12 @@ func (hc *HabitatController) onPodUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
-	if err := hc.writeLeaderIP(pod); err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
-	}
+	hc.enqueueOwningServiceGroup(pod)
 }
 
-// onPodDelete needs to check whether the Pod that has been deleted was the leader.
-// If it was, another running Pod's IP needs to be written to the ConfigMap.
+// onPodDelete enqueues the owning ServiceGroup's key so that a worker can
+// check whether the deleted Pod was the leader and, if so, promote another
+// running Pod.
 func (hc *HabitatController) onPodDelete(obj interface{}) {
 	pod, ok := obj.(*apiv1.Pod)
 	if !ok {
@@ -318,93 +595,20 @@ func (hc *HabitatController) onPodDelete(obj interface{}) {
 		return
 	}
 
+	hc.enqueueOwningServiceGroup(pod)
+}
+
+// enqueueOwningServiceGroup maps a Pod to the key of the ServiceGroup that
+// owns it, via the "service-group" label, and enqueues that key.
+func (hc *HabitatController) enqueueOwningServiceGroup(pod *apiv1.Pod) {
 	sgName, exists := pod.ObjectMeta.Labels["service-group"]
 	if !exists {
 		level.Error(hc.logger).Log("msg", "Could not retrieve service group name because label does not exist.")
 		return
 	}
 
-	cmName := configMapName(sgName)
-
-	cm, err := hc.config.KubernetesClientset.CoreV1().ConfigMaps(apiv1.NamespaceDefault).Get(cmName, metav1.GetOptions{})
-	if err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
-	}
-
-	currentLeaderIP := cm.Data[peerFile]
-	deletedPodIP := pod.Status.PodIP
-
-	// The deleted Pod was not the leader, so there's nothing to do.
-	if deletedPodIP != currentLeaderIP {
-		return
-	}
-
-	// Get only running pods.
-	fs := fields.SelectorFromSet(fields.Set{
-		"status.phase": "Running",
-	})
-
-	podList, err := hc.config.KubernetesClientset.CoreV1().Pods(apiv1.NamespaceDefault).List(metav1.ListOptions{FieldSelector: fs.String()})
-	if err != nil {
-		level.Error(hc.logger).Log("msg", err)
-		return
-	}
-
-	newLeader := podList.Items[0]
-
-	if err := hc.writeLeaderIP(&newLeader); err != nil {
-		level.Error(hc.logger).Log("msg", err)
-	}
-}
-
-// writeLeaderIP writes the IP of the Pod passed as argument to the ConfigMap, provided there isn't already a running leader.
-// This way, all subsequently running Pods will know how to join the ring.
-func (hc *HabitatController) writeLeaderIP(pod *apiv1.Pod) error {
-	sgName := pod.ObjectMeta.Labels["service-group"]
-	cmName := configMapName(sgName)
-	ip := pod.Status.PodIP
-
-	cm, err := hc.config.KubernetesClientset.CoreV1().ConfigMaps(apiv1.NamespaceDefault).Get(cmName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-
-	currentLeaderIP := cm.Data[peerFile]
-	if currentLeaderIP != "" {
-		if ip == currentLeaderIP {
-			return nil
-		}
-
-		// Is the leader still running?
-		// If so, we don't need to do anything.
-		fs := fields.SelectorFromSet(fields.Set{
-			"status.podIP": currentLeaderIP,
-			"status.phase": string(apiv1.PodRunning),
-		})
-
-		podList, err := hc.config.KubernetesClientset.CoreV1().Pods(apiv1.NamespaceDefault).List(metav1.ListOptions{FieldSelector: fs.String()})
-		if err != nil {
-			return err
-		}
-
-		if len(podList.Items) > 0 {
-			return nil
-		}
-	}
-
-	// We need to retrieve our deployment to get the UID for the OwnerReference.
-	d, err := hc.config.KubernetesClientset.AppsV1beta1Client.Deployments(apiv1.NamespaceDefault).Get(sgName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-
-	updatedCM := newConfigMap(sgName, d.UID, ip)
-	_, err = hc.config.KubernetesClientset.CoreV1().ConfigMaps(apiv1.NamespaceDefault).Update(updatedCM)
-	if err != nil {
-		return err
-	}
-	return nil
+	key := pod.ObjectMeta.Namespace + "/" + sgName
+	hc.queue.Add(key)
 }
 
 func newConfigMap(sgName string, parentUID types.UID, ip string) *apiv1.ConfigMap {
@@ -0,0 +1,167 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/go-kit/kit/log/level"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+)
+
+// finalizerName is added to every ServiceGroup the controller creates
+// resources for. Kubernetes keeps the object around, with its
+// DeletionTimestamp set, until this finalizer is removed, which gives the
+// controller a chance to tear down resources that aren't covered by owner
+// references (e.g. cross-namespace resources, or actions that aren't a
+// simple object deletion).
+const finalizerName = "habitat.kinvolk.io/finalizer"
+
+// hasFinalizer reports whether sg already carries finalizerName.
+func hasFinalizer(sg *crv1.ServiceGroup) bool {
+	for _, f := range sg.ObjectMeta.Finalizers {
+		if f == finalizerName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addFinalizer patches finalizerName onto sg so that a subsequent deletion
+// goes through finalizeServiceGroup instead of being garbage collected
+// immediately.
+func (hc *HabitatController) addFinalizer(sg *crv1.ServiceGroup) error {
+	return hc.patchFinalizers(sg, withFinalizer(sg.ObjectMeta.Finalizers))
+}
+
+// removeFinalizer patches finalizerName off of sg, allowing Kubernetes to
+// finish deleting it.
+func (hc *HabitatController) removeFinalizer(sg *crv1.ServiceGroup) error {
+	return hc.patchFinalizers(sg, withoutFinalizer(sg.ObjectMeta.Finalizers))
+}
+
+// withFinalizer returns finalizers with finalizerName appended, unless it is
+// already present.
+func withFinalizer(finalizers []string) []string {
+	for _, f := range finalizers {
+		if f == finalizerName {
+			return finalizers
+		}
+	}
+
+	return append(append([]string{}, finalizers...), finalizerName)
+}
+
+// withoutFinalizer returns finalizers with finalizerName removed, if present.
+func withoutFinalizer(finalizers []string) []string {
+	out := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizerName {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+func (hc *HabitatController) patchFinalizers(sg *crv1.ServiceGroup, finalizers []string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers": finalizers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return hc.config.HabitatClient.Patch(types.MergePatchType).
+		Namespace(sg.ObjectMeta.Namespace).
+		Resource(crv1.ServiceGroupResourcePlural).
+		Name(sg.Name).
+		Body(patch).
+		Do().
+		Error()
+}
+
+// finalizeServiceGroup runs the ordered teardown for a ServiceGroup that has
+// a DeletionTimestamp set, then removes finalizerName so Kubernetes can
+// complete the deletion. Each step tolerates the resource already being gone,
+// so finalizeServiceGroup can safely be retried after a partial failure: it
+// simply continues tearing down whatever is left.
+func (hc *HabitatController) finalizeServiceGroup(sg *crv1.ServiceGroup) error {
+	if !hasFinalizer(sg) {
+		// Nothing to do; either we never got to add the finalizer, or a
+		// previous call already finished the teardown.
+		return nil
+	}
+
+	namespace := sg.ObjectMeta.Namespace
+
+	level.Info(hc.logger).Log("msg", "tearing down ServiceGroup", "name", sg.Name, "namespace", namespace)
+
+	if err := hc.tearDownResources(namespace, sg.Name); err != nil {
+		return err
+	}
+
+	level.Info(hc.logger).Log("msg", "torn down ServiceGroup", "name", sg.Name, "namespace", namespace)
+
+	return hc.removeFinalizer(sg)
+}
+
+// tearDownResources deletes, in order, the Deployment or StatefulSet,
+// ConfigMap, Services and Secrets created on behalf of the ServiceGroup
+// named name. Each step tolerates the resource already being gone, so
+// calling it again after a previous call failed partway through picks up
+// where it left off instead of erroring on the resources that were already
+// removed.
+func (hc *HabitatController) tearDownResources(namespace, name string) error {
+	deletePolicy := metav1.DeletePropagationBackground
+	deleteOptions := &metav1.DeleteOptions{PropagationPolicy: &deletePolicy}
+
+	// Standalone ServiceGroups run as a Deployment; Leader/InitialPeer ones
+	// run as a StatefulSet (see reconcileRing). Only one of the two will
+	// ever exist for a given ServiceGroup, so deleting both is safe.
+	if err := hc.config.KubernetesClientset.AppsV1beta1().Deployments(namespace).Delete(name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := hc.config.KubernetesClientset.AppsV1beta1().StatefulSets(namespace).Delete(name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := hc.config.KubernetesClientset.CoreV1().ConfigMaps(namespace).Delete(configMapName(name), deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := hc.config.KubernetesClientset.CoreV1().Services(namespace).Delete(name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// The headless peer Service created for ring topologies.
+	if err := hc.config.KubernetesClientset.CoreV1().Services(namespace).Delete(peerServiceName(name), deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := hc.config.KubernetesClientset.CoreV1().Secrets(namespace).Delete(name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+)
+
+func TestHasFinalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		finalizers []string
+		want       bool
+	}{
+		{"no finalizers", nil, false},
+		{"other finalizer only", []string{"other.example.com/finalizer"}, false},
+		{"has finalizer", []string{finalizerName}, true},
+		{"has finalizer among others", []string{"other.example.com/finalizer", finalizerName}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := &crv1.ServiceGroup{
+				ObjectMeta: metav1.ObjectMeta{Finalizers: tt.finalizers},
+			}
+
+			if got := hasFinalizer(sg); got != tt.want {
+				t.Errorf("hasFinalizer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFinalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		finalizers []string
+		want       []string
+	}{
+		{"adds to empty list", nil, []string{finalizerName}},
+		{"adds alongside other finalizers", []string{"other.example.com/finalizer"}, []string{"other.example.com/finalizer", finalizerName}},
+		{"is a no-op when already present", []string{finalizerName}, []string{finalizerName}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withFinalizer(tt.finalizers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withFinalizer(%v) = %v, want %v", tt.finalizers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutFinalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		finalizers []string
+		want       []string
+	}{
+		{"is a no-op when absent", []string{"other.example.com/finalizer"}, []string{"other.example.com/finalizer"}},
+		{"removes the only finalizer", []string{finalizerName}, []string{}},
+		{"removes it among others", []string{"other.example.com/finalizer", finalizerName}, []string{"other.example.com/finalizer"}},
+		// This is the partial-failure-resume case: a previous
+		// finalizeServiceGroup call already removed the finalizer from the
+		// object (the final step of teardown), so a retry driven by a stale
+		// queue entry must be a no-op rather than erroring.
+		{"is idempotent on an already-clean object", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withoutFinalizer(tt.finalizers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withoutFinalizer(%v) = %v, want %v", tt.finalizers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinalizeServiceGroupNoopWithoutFinalizer(t *testing.T) {
+	// Simulates resuming after a previous finalizeServiceGroup call already
+	// ran teardown and removed the finalizer, but the object key was
+	// requeued anyway (e.g. because the informer resynced before the
+	// deletion event was processed). The retry must short-circuit instead of
+	// trying to delete already-gone resources again.
+	sg := &crv1.ServiceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sg", Namespace: "default"},
+	}
+
+	hc := &HabitatController{}
+
+	if err := hc.finalizeServiceGroup(sg); err != nil {
+		t.Fatalf("finalizeServiceGroup() on an object without the finalizer should be a no-op, got error: %s", err)
+	}
+}
+
+// TestTearDownResourcesResumesAfterPartialFailure drives tearDownResources
+// through a first call that fails partway through, then a second call
+// simulating the retry a failed reconcile triggers. It exercises the claim
+// that tearDownResources is safe to call again after a partial failure:
+// steps that already succeeded must tolerate the resource now being gone,
+// and the retry must still reach (and delete) the resources the first call
+// never got to.
+func TestTearDownResourcesResumesAfterPartialFailure(t *testing.T) {
+	const (
+		namespace = "default"
+		name      = "my-sg"
+	)
+
+	clientset := fake.NewSimpleClientset(
+		&appsv1beta1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}},
+		&apiv1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName(name), Namespace: namespace}},
+		&apiv1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}},
+		&apiv1.Service{ObjectMeta: metav1.ObjectMeta{Name: peerServiceName(name), Namespace: namespace}},
+		&apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}},
+	)
+
+	// Fail the very first delete of the peer Service, simulating an API
+	// server error partway through teardown. Every earlier step (the
+	// StatefulSet and the ConfigMap) should already have been deleted by the
+	// time this call returns its error.
+	failPeerServiceDelete := true
+	clientset.PrependReactor("delete", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleteAction := action.(k8stesting.DeleteAction)
+		if failPeerServiceDelete && deleteAction.GetName() == peerServiceName(name) {
+			failPeerServiceDelete = false
+			return true, nil, errors.New("simulated API server error")
+		}
+
+		return false, nil, nil
+	})
+
+	hc := &HabitatController{config: Config{KubernetesClientset: clientset}}
+
+	if err := hc.tearDownResources(namespace, name); err == nil {
+		t.Fatal("tearDownResources() on the first call should have returned the simulated error")
+	}
+
+	if _, err := clientset.AppsV1beta1().StatefulSets(namespace).Get(name, metav1.GetOptions{}); err == nil {
+		t.Error("StatefulSet should have been deleted before the simulated failure")
+	}
+
+	if _, err := clientset.CoreV1().ConfigMaps(namespace).Get(configMapName(name), metav1.GetOptions{}); err == nil {
+		t.Error("ConfigMap should have been deleted before the simulated failure")
+	}
+
+	// Retry: the StatefulSet, ConfigMap and the name Service are already
+	// gone, so this call must tolerate their NotFound errors and finish
+	// deleting the peer Service and the Secret.
+	if err := hc.tearDownResources(namespace, name); err != nil {
+		t.Fatalf("tearDownResources() retry after partial failure should succeed, got: %s", err)
+	}
+
+	if _, err := clientset.CoreV1().Services(namespace).Get(peerServiceName(name), metav1.GetOptions{}); err == nil {
+		t.Error("peer Service should have been deleted by the retry")
+	}
+
+	if _, err := clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{}); err == nil {
+		t.Error("Secret should have been deleted by the retry")
+	}
+}
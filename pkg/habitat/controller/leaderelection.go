@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log/level"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// runWithLeaderElection wraps run in a single acquire-lead-renew cycle so
+// that, when multiple replicas of the controller are running for HA, only
+// the elected leader reconciles resources. LeaderElector.Run performs one
+// such cycle and returns as soon as leadership is lost (or ctx is
+// canceled); it does not loop internally. Failover across replicas works
+// because every replica calls this independently: the standby ones are
+// blocked trying to acquire the lock, and whichever one succeeds next picks
+// up reconciling. A process that loses leadership here simply returns, and
+// relies on being restarted (or re-invoked) to compete for the lock again.
+func (hc *HabitatController) runWithLeaderElection(ctx context.Context) error {
+	id, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to determine leader election identity: %s", err)
+	}
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		hc.config.LeaseNamespace,
+		hc.config.LeaseName,
+		hc.config.KubernetesClientset.CoreV1(),
+		hc.config.KubernetesClientset.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create leader election lock: %s", err)
+	}
+
+	// OnStartedLeading runs in a goroutine that client-go's LeaderElector.Run
+	// spawns and does not wait on, so runErrCh (rather than a plain
+	// variable) is what lets us read hc.run's result without racing its
+	// write. started records whether OnStartedLeading ever fired, since we
+	// must not block reading runErrCh if this replica never became leader.
+	runErrCh := make(chan error, 1)
+	var started int32
+
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: hc.config.LeaseDuration,
+		RenewDeadline: hc.config.RenewDeadline,
+		RetryPeriod:   hc.config.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				level.Info(hc.logger).Log("msg", "became leader, starting controller", "identity", id)
+				atomic.StoreInt32(&started, 1)
+				runErrCh <- hc.run(leaderCtx)
+			},
+			OnStoppedLeading: func() {
+				level.Info(hc.logger).Log("msg", "lost leadership, stopping controller", "identity", id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity == id {
+					return
+				}
+				level.Info(hc.logger).Log("msg", "new leader elected", "identity", identity)
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector: %s", err)
+	}
+
+	// Run blocks until this replica either never acquires the lock or
+	// acquires it and then loses it, at which point the ctx passed to
+	// OnStartedLeading has already been canceled.
+	le.Run(ctx)
+
+	return leaderElectionResult(atomic.LoadInt32(&started) == 1, runErrCh)
+}
+
+// leaderElectionResult resolves runWithLeaderElection's return value once
+// le.Run has returned. started reports whether OnStartedLeading ever fired
+// for this replica: if it didn't, runErrCh will never receive anything, and
+// blocking on it would hang forever instead of reporting that this replica
+// simply never became leader.
+func leaderElectionResult(started bool, runErrCh <-chan error) error {
+	if !started {
+		return nil
+	}
+
+	return <-runErrCh
+}
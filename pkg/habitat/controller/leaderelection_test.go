@@ -0,0 +1,41 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLeaderElectionResultNeverStarted(t *testing.T) {
+	// Simulates a replica that never acquired the lock: OnStartedLeading
+	// never ran, so runErrCh never receives anything. Blocking on it here
+	// would hang the test (and the real caller) forever.
+	runErrCh := make(chan error, 1)
+
+	if err := leaderElectionResult(false, runErrCh); err != nil {
+		t.Errorf("leaderElectionResult(false, ...) = %v, want nil", err)
+	}
+}
+
+func TestLeaderElectionResultStarted(t *testing.T) {
+	wantErr := errors.New("run failed")
+	runErrCh := make(chan error, 1)
+	runErrCh <- wantErr
+
+	if err := leaderElectionResult(true, runErrCh); err != wantErr {
+		t.Errorf("leaderElectionResult(true, ...) = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the Prometheus collectors the controller records to. They
+// are created unregistered; RegisterMetrics adds them to a caller-supplied
+// registry so that cmd/habitat-operator controls what's exposed on
+// /metrics.
+var metrics = struct {
+	reconcileTotal    *prometheus.CounterVec
+	reconcileDuration prometheus.Histogram
+	leaderIPChanges   prometheus.Counter
+	workqueueDepth    prometheus.GaugeFunc
+}{
+	reconcileTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "habitat_reconcile_total",
+		Help: "Total number of ServiceGroup reconciles, by result.",
+	}, []string{"result"}),
+	reconcileDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "habitat_reconcile_duration_seconds",
+		Help:    "Time taken to reconcile a single ServiceGroup.",
+		Buckets: prometheus.DefBuckets,
+	}),
+	leaderIPChanges: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "habitat_leader_ip_changes_total",
+		Help: "Total number of times a ServiceGroup's leader IP changed.",
+	}),
+}
+
+// RegisterMetrics registers the controller's collectors on reg. hc's
+// workqueue depth is exposed as a GaugeFunc so its value is always read live
+// rather than needing to be kept in sync by hand.
+func (hc *HabitatController) RegisterMetrics(reg *prometheus.Registry) {
+	metrics.workqueueDepth = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "habitat_workqueue_depth",
+		Help: "Current depth of the ServiceGroup reconcile workqueue.",
+	}, func() float64 {
+		return float64(hc.queue.Len())
+	})
+
+	reg.MustRegister(
+		metrics.reconcileTotal,
+		metrics.reconcileDuration,
+		metrics.leaderIPChanges,
+		metrics.workqueueDepth,
+	)
+}
+
+// observeReconcile records the outcome and duration of a single reconcile
+// call, started at the given time.
+func observeReconcile(start time.Time, err error) {
+	metrics.reconcileDuration.Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.reconcileTotal.WithLabelValues(result).Inc()
+}
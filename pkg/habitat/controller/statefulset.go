@@ -0,0 +1,139 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/go-kit/kit/log/level"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+)
+
+// reconcileRing creates the headless Service and StatefulSet backing a
+// Leader/InitialPeer topology ServiceGroup, so that Pods get stable DNS
+// names to bootstrap a Habitat supervisor ring from, instead of racing to
+// read a single mutable peer-IP ConfigMap.
+func (hc *HabitatController) reconcileRing(sg *crv1.ServiceGroup) error {
+	namespace := sg.ObjectMeta.Namespace
+
+	svc := newPeerService(sg)
+	_, err := hc.config.KubernetesClientset.CoreV1().Services(namespace).Create(svc)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	if err == nil {
+		level.Info(hc.logger).Log("msg", "created headless peer service", "name", svc.Name)
+	}
+
+	ss := newStatefulSet(sg)
+	_, err = hc.config.KubernetesClientset.AppsV1beta1().StatefulSets(namespace).Create(ss)
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+
+	level.Info(hc.logger).Log("msg", "created StatefulSet", "name", ss.Name)
+
+	return nil
+}
+
+// peerServiceName returns the name of the headless Service backing the
+// supervisor ring for a ServiceGroup named sgName.
+func peerServiceName(sgName string) string {
+	return fmt.Sprintf("%s-peers", sgName)
+}
+
+// newPeerService returns the headless (ClusterIP: None) Service that gives
+// each Pod in the ring a stable DNS name.
+func newPeerService(sg *crv1.ServiceGroup) *apiv1.Service {
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: peerServiceName(sg.Name),
+		},
+		Spec: apiv1.ServiceSpec{
+			ClusterIP: apiv1.ClusterIPNone,
+			Selector: map[string]string{
+				"habitat":       "true",
+				"service-group": sg.Name,
+			},
+		},
+	}
+}
+
+// peerDNSNames returns the stable DNS names of every Pod the StatefulSet for
+// sg will create, in the form <name>-<ordinal>.<svc>.<namespace>.svc.
+func peerDNSNames(sg *crv1.ServiceGroup) []string {
+	svcName := peerServiceName(sg.Name)
+	namespace := sg.ObjectMeta.Namespace
+
+	names := make([]string, sg.Spec.Count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%d.%s.%s.svc", sg.Name, i, svcName, namespace)
+	}
+
+	return names
+}
+
+// newStatefulSet returns the StatefulSet backing sg's supervisor ring. Pods
+// are started with a --peer flag per peer DNS name, so that each Supervisor
+// can find the rest of the ring regardless of which Pod comes up first.
+func newStatefulSet(sg *crv1.ServiceGroup) *appsv1beta1.StatefulSet {
+	group := "default"
+	if sg.Spec.Habitat.Group != "" {
+		group = sg.Spec.Habitat.Group
+	}
+
+	count := int32(sg.Spec.Count)
+
+	args := []string{"--group", group}
+	for _, peer := range peerDNSNames(sg) {
+		args = append(args, "--peer", peer)
+	}
+
+	return &appsv1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: sg.Name,
+		},
+		Spec: appsv1beta1.StatefulSetSpec{
+			ServiceName: peerServiceName(sg.Name),
+			Replicas:    &count,
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"habitat":       "true",
+						"service-group": sg.Name,
+					},
+				},
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{
+						{
+							Name:  "habitat-service",
+							Image: sg.Spec.Image,
+							Args:  args,
+						},
+					},
+				},
+			},
+		},
+	}
+}
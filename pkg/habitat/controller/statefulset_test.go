@@ -0,0 +1,94 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+)
+
+func TestPeerDNSNames(t *testing.T) {
+	sg := &crv1.ServiceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sg", Namespace: "my-ns"},
+		Spec:       crv1.HabitatSpec{Count: 3},
+	}
+
+	want := []string{
+		"my-sg-0.my-sg-peers.my-ns.svc",
+		"my-sg-1.my-sg-peers.my-ns.svc",
+		"my-sg-2.my-sg-peers.my-ns.svc",
+	}
+
+	if got := peerDNSNames(sg); !reflect.DeepEqual(got, want) {
+		t.Errorf("peerDNSNames() = %v, want %v", got, want)
+	}
+}
+
+func TestNewStatefulSet(t *testing.T) {
+	sg := &crv1.ServiceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sg", Namespace: "my-ns"},
+		Spec: crv1.HabitatSpec{
+			Image:   "habitat/my-service",
+			Count:   2,
+			Habitat: crv1.HabitatComponent{Group: "prod"},
+		},
+	}
+
+	ss := newStatefulSet(sg)
+
+	if ss.Name != "my-sg" {
+		t.Errorf("Name = %q, want %q", ss.Name, "my-sg")
+	}
+
+	if ss.Spec.ServiceName != "my-sg-peers" {
+		t.Errorf("ServiceName = %q, want %q", ss.Spec.ServiceName, "my-sg-peers")
+	}
+
+	if got := *ss.Spec.Replicas; got != 2 {
+		t.Errorf("Replicas = %d, want 2", got)
+	}
+
+	container := ss.Spec.Template.Spec.Containers[0]
+	if container.Image != "habitat/my-service" {
+		t.Errorf("Image = %q, want %q", container.Image, "habitat/my-service")
+	}
+
+	want := []string{
+		"--group", "prod",
+		"--peer", "my-sg-0.my-sg-peers.my-ns.svc",
+		"--peer", "my-sg-1.my-sg-peers.my-ns.svc",
+	}
+	if !reflect.DeepEqual(container.Args, want) {
+		t.Errorf("Args = %v, want %v", container.Args, want)
+	}
+}
+
+func TestNewStatefulSetDefaultsGroup(t *testing.T) {
+	sg := &crv1.ServiceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sg"},
+		Spec:       crv1.HabitatSpec{Count: 1},
+	}
+
+	ss := newStatefulSet(sg)
+
+	container := ss.Spec.Template.Spec.Containers[0]
+	if len(container.Args) < 2 || container.Args[1] != "default" {
+		t.Errorf("Args = %v, want group defaulted to %q", container.Args, "default")
+	}
+}
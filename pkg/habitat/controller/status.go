@@ -0,0 +1,187 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	crv1 "github.com/kinvolk/habitat-operator/pkg/habitat/apis/cr/v1"
+)
+
+const (
+	conditionReady         = "Ready"
+	conditionPeerElected   = "PeerElected"
+	conditionConfigApplied = "ConfigApplied"
+)
+
+// updateStatus recomputes sg's status from cluster state and patches the
+// /status subresource. It is called at the end of every reconcile, whether
+// it succeeded or not, so status always reflects the last observed
+// generation, even if the spec hasn't changed since.
+//
+// This uses a merge patch rather than a Put of the full object, because sg
+// may carry a ResourceVersion that's already stale by the time we get here:
+// reconcile calls addFinalizer, a separate Patch that bumps the real
+// ResourceVersion, immediately before updateStatus runs on the same
+// in-memory sg. A Put with the old ResourceVersion would conflict with that
+// write on every first reconcile of a new ServiceGroup.
+func (hc *HabitatController) updateStatus(sg *crv1.ServiceGroup, reconcileErr error) {
+	status := hc.computeStatus(sg, reconcileErr)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": status,
+	})
+	if err != nil {
+		level.Error(hc.logger).Log("msg", "failed to marshal status patch", "name", sg.Name, "err", err)
+		return
+	}
+
+	err = hc.config.HabitatClient.Patch(types.MergePatchType).
+		Namespace(sg.ObjectMeta.Namespace).
+		Resource(crv1.ServiceGroupResourcePlural).
+		Name(sg.Name).
+		SubResource("status").
+		Body(patch).
+		Do().
+		Error()
+	if err != nil {
+		level.Error(hc.logger).Log("msg", "failed to update status", "name", sg.Name, "err", err)
+	}
+}
+
+// computeStatus derives a HabitatStatus for sg from the Pods and peer-file
+// ConfigMap currently on the cluster.
+func (hc *HabitatController) computeStatus(sg *crv1.ServiceGroup, reconcileErr error) crv1.HabitatStatus {
+	namespace := sg.ObjectMeta.Namespace
+
+	ls := labels.SelectorFromSet(labels.Set{"service-group": sg.Name})
+	podList, err := hc.config.KubernetesClientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: ls.String()})
+
+	var replicas, readyReplicas int32
+	if err == nil {
+		replicas = int32(len(podList.Items))
+		for _, pod := range podList.Items {
+			if isPodReady(&pod) {
+				readyReplicas++
+			}
+		}
+	}
+
+	// Standalone ServiceGroups elect a leader into the peer-file ConfigMap;
+	// ring topologies (Leader/InitialPeer) have no such ConfigMap (see
+	// reconcileRing) and instead form their ring directly over the peer
+	// Service, so a peer is considered elected there once any ring member is
+	// ready to be resolved through it.
+	var leaderIP string
+	var peerElected bool
+	if sg.Spec.Service.Topology == crv1.TopologyStandalone {
+		cm, err := hc.config.KubernetesClientset.CoreV1().ConfigMaps(namespace).Get(configMapName(sg.Name), metav1.GetOptions{})
+		if err == nil {
+			leaderIP = cm.Data[peerFile]
+		} else if !apierrors.IsNotFound(err) {
+			level.Error(hc.logger).Log("msg", "failed to read peer-file ConfigMap for status", "name", sg.Name, "err", err)
+		}
+
+		peerElected = leaderIP != ""
+	} else {
+		peerElected = readyReplicas > 0
+	}
+
+	now := metav1.NewTime(time.Now())
+
+	return crv1.HabitatStatus{
+		ObservedGeneration: sg.ObjectMeta.Generation,
+		Replicas:           replicas,
+		ReadyReplicas:      readyReplicas,
+		LeaderIP:           leaderIP,
+		Conditions: []crv1.HabitatCondition{
+			readyCondition(reconcileErr, replicas, readyReplicas, now),
+			peerElectedCondition(peerElected, now),
+			configAppliedCondition(reconcileErr, now),
+		},
+	}
+}
+
+func readyCondition(reconcileErr error, replicas, readyReplicas int32, now metav1.Time) crv1.HabitatCondition {
+	c := crv1.HabitatCondition{
+		Type:               conditionReady,
+		Status:             apiv1.ConditionFalse,
+		Reason:             "ReplicasNotReady",
+		Message:            "not all replicas are ready",
+		LastTransitionTime: now,
+	}
+
+	if reconcileErr == nil && replicas > 0 && readyReplicas == replicas {
+		c.Status = apiv1.ConditionTrue
+		c.Reason = "AllReplicasReady"
+		c.Message = "all replicas are ready"
+	}
+
+	return c
+}
+
+func peerElectedCondition(peerElected bool, now metav1.Time) crv1.HabitatCondition {
+	c := crv1.HabitatCondition{
+		Type:               conditionPeerElected,
+		Status:             apiv1.ConditionFalse,
+		Reason:             "NoPeerElected",
+		Message:            "no Pod has been elected as the initial peer yet",
+		LastTransitionTime: now,
+	}
+
+	if peerElected {
+		c.Status = apiv1.ConditionTrue
+		c.Reason = "PeerElected"
+		c.Message = "an initial peer has been elected"
+	}
+
+	return c
+}
+
+func configAppliedCondition(reconcileErr error, now metav1.Time) crv1.HabitatCondition {
+	c := crv1.HabitatCondition{
+		Type:               conditionConfigApplied,
+		Status:             apiv1.ConditionTrue,
+		Reason:             "ConfigApplied",
+		Message:            "the Deployment/StatefulSet and peer-file ConfigMap match the spec",
+		LastTransitionTime: now,
+	}
+
+	if reconcileErr != nil {
+		c.Status = apiv1.ConditionFalse
+		c.Reason = "ReconcileError"
+		c.Message = reconcileErr.Error()
+	}
+
+	return c
+}
+
+func isPodReady(pod *apiv1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady {
+			return cond.Status == apiv1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadyCondition(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name          string
+		reconcileErr  error
+		replicas      int32
+		readyReplicas int32
+		wantStatus    apiv1.ConditionStatus
+	}{
+		{"all ready, no error", nil, 3, 3, apiv1.ConditionTrue},
+		{"some not ready", nil, 3, 2, apiv1.ConditionFalse},
+		{"no replicas yet", nil, 0, 0, apiv1.ConditionFalse},
+		{"reconcile error even if ready", errors.New("boom"), 3, 3, apiv1.ConditionFalse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := readyCondition(tt.reconcileErr, tt.replicas, tt.readyReplicas, now)
+			if c.Type != conditionReady {
+				t.Errorf("Type = %q, want %q", c.Type, conditionReady)
+			}
+			if c.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", c.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPeerElectedCondition(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name        string
+		peerElected bool
+		wantStatus  apiv1.ConditionStatus
+		wantReason  string
+	}{
+		{"no peer elected", false, apiv1.ConditionFalse, "NoPeerElected"},
+		{"peer elected", true, apiv1.ConditionTrue, "PeerElected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := peerElectedCondition(tt.peerElected, now)
+			if c.Type != conditionPeerElected {
+				t.Errorf("Type = %q, want %q", c.Type, conditionPeerElected)
+			}
+			if c.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", c.Status, tt.wantStatus)
+			}
+			if c.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", c.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestConfigAppliedCondition(t *testing.T) {
+	now := metav1.Now()
+
+	t.Run("no error", func(t *testing.T) {
+		c := configAppliedCondition(nil, now)
+		if c.Status != apiv1.ConditionTrue {
+			t.Errorf("Status = %q, want %q", c.Status, apiv1.ConditionTrue)
+		}
+	})
+
+	t.Run("reconcile error", func(t *testing.T) {
+		err := errors.New("could not create StatefulSet")
+		c := configAppliedCondition(err, now)
+		if c.Status != apiv1.ConditionFalse {
+			t.Errorf("Status = %q, want %q", c.Status, apiv1.ConditionFalse)
+		}
+		if c.Message != err.Error() {
+			t.Errorf("Message = %q, want %q", c.Message, err.Error())
+		}
+	})
+}
+
+func TestIsPodReady(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *apiv1.Pod
+		want bool
+	}{
+		{"no conditions", &apiv1.Pod{}, false},
+		{
+			"ready condition true",
+			&apiv1.Pod{Status: apiv1.PodStatus{Conditions: []apiv1.PodCondition{
+				{Type: apiv1.PodReady, Status: apiv1.ConditionTrue},
+			}}},
+			true,
+		},
+		{
+			"ready condition false",
+			&apiv1.Pod{Status: apiv1.PodStatus{Conditions: []apiv1.PodCondition{
+				{Type: apiv1.PodReady, Status: apiv1.ConditionFalse},
+			}}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPodReady(tt.pod); got != tt.want {
+				t.Errorf("isPodReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -42,6 +42,25 @@ func NewStandaloneHabitat(habitatName, group, image string) *crv1.Habitat {
 	}
 }
 
+// NewLeaderHabitat returns a new Habitat using the Leader topology, which
+// forms a supervisor ring via a StatefulSet and headless Service instead of
+// a single Deployment.
+func NewLeaderHabitat(habitatName, group, image string) *crv1.Habitat {
+	habitat := NewStandaloneHabitat(habitatName, group, image)
+	habitat.Spec.Service.Topology = crv1.TopologyLeader
+
+	return habitat
+}
+
+// NewInitialPeerHabitat returns a new Habitat using the InitialPeer
+// topology, used to seed a ring that other Habitats join as followers.
+func NewInitialPeerHabitat(habitatName, group, image string) *crv1.Habitat {
+	habitat := NewStandaloneHabitat(habitatName, group, image)
+	habitat.Spec.Service.Topology = crv1.TopologyInitialPeer
+
+	return habitat
+}
+
 // AddConfigToHabitat adds a ConfigSecretName field to the Habitat.
 func AddConfigToHabitat(habitat *crv1.Habitat) {
 	habitat.Spec.Service.ConfigSecretName = habitat.ObjectMeta.Name
@@ -56,10 +75,16 @@ func AddBindToHabitat(habitat *crv1.Habitat, bindName, bindService string) {
 	})
 }
 
-// CreateHabitat creates a Habitat.
+// CreateHabitat creates a Habitat in TestNs.
 func (f *Framework) CreateHabitat(habitat *crv1.Habitat) error {
+	return f.CreateHabitatInNamespace(habitat, TestNs)
+}
+
+// CreateHabitatInNamespace creates a Habitat in an arbitrary namespace, for
+// exercising multi-namespace support.
+func (f *Framework) CreateHabitatInNamespace(habitat *crv1.Habitat, namespace string) error {
 	return f.Client.Post().
-		Namespace(TestNs).
+		Namespace(namespace).
 		Resource(crv1.HabitatResourcePlural).
 		Body(habitat).
 		Do().
@@ -92,9 +117,11 @@ func (f *Framework) WaitForResources(habitatName string, numPods int) error {
 	})
 }
 
-func (f *Framework) WaitForEndpoints(habitatName string) error {
+// WaitForEndpoints waits for the Service named serviceName to have at least
+// one address registered in its Endpoints.
+func (f *Framework) WaitForEndpoints(serviceName string) error {
 	return wait.Poll(time.Second, time.Minute*5, func() (bool, error) {
-		ep, err := f.KubeClient.CoreV1().Endpoints(TestNs).Get(habitatName, metav1.GetOptions{})
+		ep, err := f.KubeClient.CoreV1().Endpoints(TestNs).Get(serviceName, metav1.GetOptions{})
 		if err != nil {
 			return false, err
 		}
@@ -107,6 +134,13 @@ func (f *Framework) WaitForEndpoints(habitatName string) error {
 	})
 }
 
+// PeerServiceName returns the name of the headless Service backing a ring
+// topology Habitat's supervisor ring, as created by the controller's
+// reconcileRing.
+func PeerServiceName(habitatName string) string {
+	return habitatName + "-peers"
+}
+
 // DeleteHabitat deletes a Habitat as a user would.
 func (f *Framework) DeleteHabitat(habitatName string) error {
 	return f.Client.Delete().
@@ -120,3 +154,9 @@ func (f *Framework) DeleteHabitat(habitatName string) error {
 func (f *Framework) DeleteService(service string) error {
 	return f.KubeClient.CoreV1().Services(TestNs).Delete(service, &metav1.DeleteOptions{})
 }
+
+// DeletePod deletes a single Pod by name, e.g. to exercise a StatefulSet
+// replacing and re-joining a ring member.
+func (f *Framework) DeletePod(name string) error {
+	return f.KubeClient.CoreV1().Pods(TestNs).Delete(name, &metav1.DeleteOptions{})
+}
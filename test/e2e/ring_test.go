@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Chef Software Inc. and/or applicable contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/kinvolk/habitat-operator/test/e2e/framework"
+)
+
+// TestRingFormation creates an InitialPeer-topology Habitat and checks that all of
+// its Pods come up and register an Endpoint, i.e. that the supervisor ring
+// formed over the StatefulSet's headless Service.
+func TestRingFormation(t *testing.T) {
+	habitat := framework.NewInitialPeerHabitat("ring-formation", "default", testImage)
+
+	if err := f.CreateHabitat(habitat); err != nil {
+		t.Fatalf("could not create Habitat: %s", err)
+	}
+	defer f.DeleteHabitat(habitat.Name)
+
+	if err := f.WaitForResources(habitat.Name, 1); err != nil {
+		t.Fatalf("initial peer Pod did not become ready: %s", err)
+	}
+
+	if err := f.WaitForEndpoints(framework.PeerServiceName(habitat.Name)); err != nil {
+		t.Fatalf("ring did not register any Endpoints: %s", err)
+	}
+}
+
+// TestRingFormationSurvivesPodRestart checks that, after a Pod in the ring
+// is deleted, the StatefulSet replaces it and the replacement rejoins the
+// ring using the same stable DNS name instead of the operator having to pick
+// a new leader IP.
+func TestRingFormationSurvivesPodRestart(t *testing.T) {
+	habitat := framework.NewLeaderHabitat("ring-restart", "default", testImage)
+
+	if err := f.CreateHabitat(habitat); err != nil {
+		t.Fatalf("could not create Habitat: %s", err)
+	}
+	defer f.DeleteHabitat(habitat.Name)
+
+	if err := f.WaitForResources(habitat.Name, 1); err != nil {
+		t.Fatalf("leader Pod did not become ready: %s", err)
+	}
+
+	// Deleting the leader Pod should not change its stable DNS name; the
+	// StatefulSet recreates it as <name>-0 again.
+	if err := f.DeletePod(habitat.Name + "-0"); err != nil {
+		t.Fatalf("could not delete leader Pod: %s", err)
+	}
+
+	if err := f.WaitForResources(habitat.Name, 1); err != nil {
+		t.Fatalf("leader Pod was not recreated after restart: %s", err)
+	}
+
+	if err := f.WaitForEndpoints(framework.PeerServiceName(habitat.Name)); err != nil {
+		t.Fatalf("ring did not reform after Pod restart: %s", err)
+	}
+}